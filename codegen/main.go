@@ -16,11 +16,16 @@ package main
 
 import (
 	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 )
@@ -67,12 +72,111 @@ namespace Nakama
         }
     }
 
+    /// <summary>
+    /// An <c>ApiResponseException</c> whose body could be deserialized into a typed error, e.g.
+    /// Nakama's <c>rpcStatus</c>/<c>googleRpcStatus</c> responses.
+    /// </summary>
+    public sealed class ApiResponseException<TError> : ApiResponseException
+    {
+        public TError ErrorBody { get; }
+
+        public ApiResponseException(long statusCode, string content, int grpcCode, TError errorBody) : base(statusCode, content, grpcCode)
+        {
+            ErrorBody = errorBody;
+        }
+    }
+
     {{- range $defname, $definition := .Definitions }}
     {{- $classname := $defname | title }}
+    {{- if $definition.EnumMembers }}
+
+    /// <summary>
+    /// {{ $definition.Description | stripNewlines }}
+    /// </summary>
+    {{- if $definition.Deprecated }}
+    [Obsolete("{{ if $definition.Description }}{{ $definition.Description | stripNewlines }}{{ else }}Deprecated in API{{ end }}")]
+    {{- end }}
+    public enum {{ $classname }}
+    {
+        {{- range $member := $definition.EnumMembers }}
+        {{- if $member.Description }}
+
+        /// <summary>
+        /// {{ $member.Description }}
+        /// </summary>
+        {{- end }}
+        [EnumMember(Value = "{{ $member.Value }}")]
+        {{ $member.Identifier }},
+        {{- end }}
+    }
+    {{- else if $definition.Discriminator }}
+
+    /// <summary>
+    /// {{ $definition.Description | stripNewlines }}
+    /// </summary>
+    {{- if $definition.Deprecated }}
+    [Obsolete("{{ if $definition.Description }}{{ $definition.Description | stripNewlines }}{{ else }}Deprecated in API{{ end }}")]
+    {{- end }}
+    public sealed class {{ $classname }}
+    {
+        public string {{ $definition.Discriminator.PropertyName | pascalCase }} { get; set; }
+
+        {{- range $member := $definition.OneOf }}
+        public {{ $member }} As{{ $member }} { get; set; }
+        {{- end }}
+
+        public static {{ $classname }} FromJson(string json)
+        {
+            var wrapper = new {{ $classname }}();
+            var raw = json.FromJson<Dictionary<string, object>>();
+            if (raw != null && raw.TryGetValue("{{ $definition.Discriminator.PropertyName }}", out var discriminatorValue))
+            {
+                wrapper.{{ $definition.Discriminator.PropertyName | pascalCase }} = discriminatorValue.ToString();
+                switch (wrapper.{{ $definition.Discriminator.PropertyName | pascalCase }})
+                {
+                    {{- range $member := $definition.OneOf }}
+                    case "{{ $member }}":
+                        wrapper.As{{ $member }} = json.FromJson<{{ $member }}>();
+                        break;
+                    {{- end }}
+                }
+            }
+            return wrapper;
+        }
+    }
+    {{- else }}
+    {{- range $propname, $property := $definition.Properties }}
+    {{- if $property.EnumMembers }}
+    {{- $fieldname := $propname | pascalCase }}
+
+    /// <summary>
+    /// {{ $property.Description }}
+    /// </summary>
+    {{- if $property.Deprecated }}
+    [Obsolete("{{ if $property.Description }}{{ $property.Description | stripNewlines }}{{ else }}Deprecated in API{{ end }}")]
+    {{- end }}
+    public enum {{ $classname }}{{ $fieldname }}
+    {
+        {{- range $member := $property.EnumMembers }}
+        {{- if $member.Description }}
+
+        /// <summary>
+        /// {{ $member.Description }}
+        /// </summary>
+        {{- end }}
+        [EnumMember(Value = "{{ $member.Value }}")]
+        {{ $member.Identifier }},
+        {{- end }}
+    }
+    {{- end }}
+    {{- end }}
 
     /// <summary>
     /// {{ $definition.Description | stripNewlines }}
     /// </summary>
+    {{- if $definition.Deprecated }}
+    [Obsolete("{{ if $definition.Description }}{{ $definition.Description | stripNewlines }}{{ else }}Deprecated in API{{ end }}")]
+    {{- end }}
     public interface I{{ $classname }}
     {
         {{- range $propname, $property := $definition.Properties }}
@@ -81,19 +185,17 @@ namespace Nakama
         /// <summary>
         /// {{ $property.Description }}
         /// </summary>
-        {{- if eq $property.Type "integer"}}
-        int {{ $fieldname }} { get; }
-        {{- else if eq $property.Type "boolean" }}
-        bool {{ $fieldname }} { get; }
-        {{- else if eq $property.Type "string"}}
-        string {{ $fieldname }} { get; }
+        {{- if $property.Deprecated }}
+        [Obsolete("{{ if $property.Description }}{{ $property.Description | stripNewlines }}{{ else }}Deprecated in API{{ end }}")]
+        {{- end }}
+        {{- if $property.EnumMembers }}
+        {{ $classname }}{{ $fieldname }} {{ $fieldname }} { get; }
+        {{- else if or (eq $property.Type "integer") (eq $property.Type "number") (eq $property.Type "boolean") (eq $property.Type "string") }}
+            {{- $cstype := primType $property.Type $property.Format }}
+        {{ $cstype }}{{- if and $property.Nullable (isValueType $cstype) }}?{{- end }} {{ $fieldname }} { get; }
         {{- else if eq $property.Type "array"}}
-            {{- if eq $property.Items.Type "string"}}
-        List<string> {{ $fieldname }} { get; }
-            {{- else if eq $property.Items.Type "integer"}}
-        List<int> {{ $fieldname }} { get; }
-            {{- else if eq $property.Items.Type "boolean"}}
-        List<bool> {{ $fieldname }} { get; }
+            {{- if or (eq $property.Items.Type "string") (eq $property.Items.Type "integer") (eq $property.Items.Type "number") (eq $property.Items.Type "boolean") }}
+        List<{{ primType $property.Items.Type $property.Items.Format }}> {{ $fieldname }} { get; }
             {{- else}}
         IEnumerable<I{{ $property.Items.Ref | cleanRef }}> {{ $fieldname }} { get; }
             {{- end }}
@@ -114,51 +216,49 @@ namespace Nakama
     }
 
     /// <inheritdoc />
+    {{- if $definition.Deprecated }}
+    [Obsolete("{{ if $definition.Description }}{{ $definition.Description | stripNewlines }}{{ else }}Deprecated in API{{ end }}")]
+    {{- end }}
     internal class {{ $classname }} : I{{ $classname }}
     {
         {{- range $propname, $property := $definition.Properties }}
         {{- $fieldname := $propname | pascalCase }}
 
         /// <inheritdoc />
-        {{- if eq $property.Type "integer" }}
-        [DataMember(Name="{{ $propname }}")]
-        public int {{ $fieldname }} { get; set; }
-        {{- else if eq $property.Type "boolean" }}
+        {{- if $property.Deprecated }}
+        [Obsolete("{{ if $property.Description }}{{ $property.Description | stripNewlines }}{{ else }}Deprecated in API{{ end }}")]
+        {{- end }}
+        {{- if $property.EnumMembers }}
         [DataMember(Name="{{ $propname }}")]
-        public bool {{ $fieldname }} { get; set; }
-        {{- else if eq $property.Type "string" }}
+        public {{ $classname }}{{ $fieldname }} {{ $fieldname }} { get; set; }
+        {{- else if or (eq $property.Type "integer") (eq $property.Type "number") (eq $property.Type "boolean") (eq $property.Type "string") }}
+            {{- $cstype := primType $property.Type $property.Format }}
         [DataMember(Name="{{ $propname }}")]
-        public string {{ $fieldname }} { get; set; }
+        public {{ $cstype }}{{- if and $property.Nullable (isValueType $cstype) }}?{{- end }} {{ $fieldname }} { get; set; }
         {{- else if eq $property.Type "array" }}
-            {{- if eq $property.Items.Type "string" }}
+            {{- if or (eq $property.Items.Type "string") (eq $property.Items.Type "integer") (eq $property.Items.Type "number") (eq $property.Items.Type "boolean") }}
         [DataMember(Name="{{ $propname }}")]
-        public List<string> {{ $fieldname }} { get; set; }
-            {{- else if eq $property.Items.Type "integer" }}
-        [DataMember(Name="{{ $propname }}")]
-        public List<int> {{ $fieldname }} { get; set; }
-            {{- else if eq $property.Items.Type "boolean" }}
-        [DataMember(Name="{{ $propname }}")]
-        public List<bool> {{ $fieldname }} { get; set; }
+        public List<{{ primType $property.Items.Type $property.Items.Format }}> {{ $fieldname }} { get; set; }
             {{- else}}
-        public IEnumerable<I{{ $property.Items.Ref | cleanRef }}> {{ $fieldname }} => _{{ $propname | camelCase }} ?? new List<{{ $property.Items.Ref | cleanRef }}>(0);
+        public IEnumerable<I{{ $property.Items.Ref | cleanRef }}> {{ $fieldname }} => _{{ $propname | camelCase }}{{- if not $property.Nullable }} ?? new List<{{ $property.Items.Ref | cleanRef }}>(0){{- end }};
         [DataMember(Name="{{ $propname }}")]
         public List<{{ $property.Items.Ref | cleanRef }}> _{{ $propname | camelCase }} { get; set; }
             {{- end }}
         {{- else if eq $property.Type "object"}}
             {{- if eq $property.AdditionalProperties.Type "string"}}
-        public IDictionary<string, string> {{ $fieldname }} => _{{ $propname | camelCase }} ?? new Dictionary<string, string>();
+        public IDictionary<string, string> {{ $fieldname }} => _{{ $propname | camelCase }}{{- if not $property.Nullable }} ?? new Dictionary<string, string>(){{- end }};
         [DataMember(Name="{{ $propname }}")]
         public Dictionary<string, string> _{{ $propname | camelCase }} { get; set; }
             {{- else if eq $property.Items.Type "integer"}}
-        public IDictionary<string, int> {{ $fieldname }} => _{{ $propname | camelCase }} ?? new Dictionary<string, int>();
+        public IDictionary<string, int> {{ $fieldname }} => _{{ $propname | camelCase }}{{- if not $property.Nullable }} ?? new Dictionary<string, int>(){{- end }};
         [DataMember(Name="{{ $propname }}")]
         public Dictionary<string, int> _{{ $propname | camelCase }} { get; set; }
             {{- else if eq $property.Items.Type "boolean"}}
-        public IDictionary<string, bool> {{ $fieldname }} => _{{ $propname | camelCase }} ?? new Dictionary<string, bool>();
+        public IDictionary<string, bool> {{ $fieldname }} => _{{ $propname | camelCase }}{{- if not $property.Nullable }} ?? new Dictionary<string, bool>(){{- end }};
         [DataMember(Name="{{ $propname }}")]
         public Dictionary<string, bool> _{{ $propname | camelCase }} { get; set; }
             {{- else}}
-        public IDictionary<string, {{$property.AdditionalProperties | cleanRef}}> {{ $fieldname }}  => _{{ $propname | camelCase }} ?? new Dictionary<string, {{$property.AdditionalProperties | cleanRef}}>();
+        public IDictionary<string, {{$property.AdditionalProperties | cleanRef}}> {{ $fieldname }}  => _{{ $propname | camelCase }}{{- if not $property.Nullable }} ?? new Dictionary<string, {{$property.AdditionalProperties | cleanRef}}>(){{- end }};
         [DataMember(Name="{{ $propname }}")]
         public Dictionary<string, {{$property.AdditionalProperties | cleanRef}}> _{{ $propname | camelCase }} { get; set; }
             {{- end}}
@@ -173,6 +273,7 @@ namespace Nakama
         {
             var output = "";
             {{- range $fieldname, $property := $definition.Properties }}
+            {{- $cstype := primType $property.Type $property.Format }}
             {{- if eq $property.Type "array" }}
             output = string.Concat(output, "{{ $fieldname | pascalCase }}: [", string.Join(", ", {{ $fieldname | pascalCase }}), "], ");
             {{- else if eq $property.Type "object" }}
@@ -183,6 +284,10 @@ namespace Nakama
                 mapString = string.Concat(mapString, "{" + kvp.Key + "=" + kvp.Value + "}");
             }
             output = string.Concat(output, "{{ $fieldname | pascalCase }}: [" + mapString + "]");
+            {{- else if eq $cstype "DateTime" }}
+            output = string.Concat(output, "{{ $fieldname | pascalCase }}: ", {{ $fieldname | pascalCase }}.ToString("o"), ", ");
+            {{- else if eq $cstype "byte[]" }}
+            output = string.Concat(output, "{{ $fieldname | pascalCase }}: ", Convert.ToBase64String({{ $fieldname | pascalCase }} ?? new byte[0]), ", ");
             {{- else }}
             output = string.Concat(output, "{{ $fieldname | pascalCase }}: ", {{ $fieldname | pascalCase }}, ", ");
             {{- end }}
@@ -191,6 +296,7 @@ namespace Nakama
         }
     }
     {{- end }}
+    {{- end }}
 
     /// <summary>
     /// The low level client for the Nakama API.
@@ -210,12 +316,29 @@ namespace Nakama
 
         {{- range $url, $path := .Paths }}
         {{- range $method, $operation := $path}}
+        {{- $okResponse := $operation.SuccessResponse }}
+        {{- $errResponse := $operation.ErrorResponse }}
 
         /// <summary>
         /// {{ $operation.Summary | stripNewlines }}
         /// </summary>
-        {{- if $operation.Responses.Ok.Schema.Ref }}
-        public async Task<I{{ $operation.Responses.Ok.Schema.Ref | cleanRef }}> {{ $operation.OperationId | pascalCase }}Async(
+        {{- if $operation.Description }}
+        /// <remarks>
+        /// {{ $operation.Description | stripNewlines }}
+        /// </remarks>
+        {{- end }}
+        {{- range $parameter := $operation.Parameters }}
+        {{- if $parameter.Description }}
+        /// <param name="{{ $parameter.Name | camelCase }}">{{ $parameter.Description | stripNewlines }}</param>
+        {{- end }}
+        {{- end }}
+        {{- if $operation.Deprecated }}
+        [Obsolete("{{ if $operation.Description }}{{ $operation.Description | stripNewlines }}{{ else }}Deprecated in API{{ end }}")]
+        {{- else if deprecatedParameterMessage $operation.Parameters }}
+        [Obsolete("{{ deprecatedParameterMessage $operation.Parameters }}")]
+        {{- end }}
+        {{- if and $okResponse $okResponse.Schema }}
+        public async Task<I{{ $okResponse.Schema.Ref | cleanRef }}> {{ $operation.OperationId | pascalCase }}Async(
         {{- else }}
         public async Task {{ $operation.OperationId | pascalCase }}Async(
         {{- end}}
@@ -237,8 +360,9 @@ namespace Nakama
 
         {{- range $parameter := $operation.Parameters }}
         {{- $camelcase := $parameter.Name | camelCase }}
+        {{- $paramCstype := primType $parameter.Type $parameter.Format }}
         {{- if eq $parameter.In "path" }}
-            , {{ $parameter.Type }}{{- if not $parameter.Required }}?{{- end }} {{ $camelcase }}
+            , {{ $paramCstype }}{{- if not $parameter.Required }}?{{- end }} {{ $camelcase }}
         {{- else if eq $parameter.In "body" }}
             {{- if eq $parameter.Schema.Type "string" }}
             , string{{- if not $parameter.Required }}?{{- end }} {{ $camelcase }}
@@ -246,7 +370,7 @@ namespace Nakama
             , {{ $parameter.Schema.Ref | cleanRef }}{{- if not $parameter.Required }}?{{- end }} {{ $camelcase }}
             {{- end }}
         {{- else if eq $parameter.Type "array"}}
-            , IEnumerable<{{ $parameter.Items.Type }}> {{ $camelcase }}
+            , IEnumerable<{{ primType $parameter.Items.Type $parameter.Items.Format }}> {{ $camelcase }}
         {{- else if eq $parameter.Type "object"}}
             {{- if eq $parameter.AdditionalProperties.Type "string"}}
         IDictionary<string, string> {{ $camelcase }}
@@ -257,20 +381,15 @@ namespace Nakama
             {{- else}}
         IDictionary<string, {{ $parameter.Items.Type }}> {{ $camelcase }}
             {{- end}}
-        {{- else if eq $parameter.Type "integer" }}
-            , int? {{ $camelcase }}
-        {{- else if eq $parameter.Type "boolean" }}
-            , bool? {{ $camelcase }}
-        {{- else if eq $parameter.Type "string" }}
-            , string {{ $camelcase }}
         {{- else }}
-            , {{ $parameter.Type }} {{ $camelcase }}
+            , {{ $paramCstype }}{{- if isValueType $paramCstype }}?{{- end }} {{ $camelcase }}
         {{- end }}
         {{- end }})
         {
             {{- range $parameter := $operation.Parameters }}
             {{- $camelcase := $parameter.Name | camelCase }}
-            {{- if $parameter.Required }}
+            {{- $paramCstype := primType $parameter.Type $parameter.Format }}
+            {{- if and $parameter.Required (not (isValueType $paramCstype)) }}
             if ({{ $camelcase }} == null)
             {
                 throw new ArgumentException("'{{ $camelcase }}' is required but was null.");
@@ -281,16 +400,48 @@ namespace Nakama
             var urlpath = "{{- $url }}";
             {{- range $parameter := $operation.Parameters }}
             {{- $camelcase := $parameter.Name | camelCase }}
+            {{- $paramCstype := primType $parameter.Type $parameter.Format }}
+            {{- $paramvalue := $camelcase }}
+            {{- if and (isValueType $paramCstype) (not $parameter.Required) }}
+                {{- $paramvalue = print $camelcase ".Value" }}
+            {{- end }}
             {{- if eq $parameter.In "path" }}
-            urlpath = urlpath.Replace("{{- print "{" $parameter.Name "}"}}", Uri.EscapeDataString({{- $camelcase }}));
+                {{- if eq $paramCstype "DateTime" }}
+            urlpath = urlpath.Replace("{{- print "{" $parameter.Name "}"}}", Uri.EscapeDataString({{ $paramvalue }}.ToString("o")));
+                {{- else if eq $paramCstype "Guid" }}
+            urlpath = urlpath.Replace("{{- print "{" $parameter.Name "}"}}", {{ $paramvalue }}.ToString());
+                {{- else if eq $paramCstype "byte[]" }}
+            urlpath = urlpath.Replace("{{- print "{" $parameter.Name "}"}}", Uri.EscapeDataString(Convert.ToBase64String({{ $camelcase }})));
+                {{- else if eq $paramCstype "string" }}
+            urlpath = urlpath.Replace("{{- print "{" $parameter.Name "}"}}", Uri.EscapeDataString({{ $paramvalue }}));
+                {{- else }}
+            urlpath = urlpath.Replace("{{- print "{" $parameter.Name "}"}}", Uri.EscapeDataString({{ $paramvalue }}.ToString()));
+                {{- end }}
             {{- end }}
             {{- end }}
 
             var queryParams = "";
             {{- range $parameter := $operation.Parameters }}
             {{- $camelcase := $parameter.Name | camelCase }}
+            {{- $paramCstype := primType $parameter.Type $parameter.Format }}
             {{- if eq $parameter.In "query"}}
-                {{- if eq $parameter.Type "integer" }}
+                {{- if eq $paramCstype "DateTime" }}
+            if ({{ $camelcase }} != null) {
+                queryParams = string.Concat(queryParams, "{{- $parameter.Name }}=", Uri.EscapeDataString({{ $camelcase }}.Value.ToString("o")), "&");
+            }
+                {{- else if eq $paramCstype "Guid" }}
+            if ({{ $camelcase }} != null) {
+                queryParams = string.Concat(queryParams, "{{- $parameter.Name }}=", {{ $camelcase }}.Value.ToString(), "&");
+            }
+                {{- else if eq $paramCstype "byte[]" }}
+            if ({{ $camelcase }} != null) {
+                queryParams = string.Concat(queryParams, "{{- $parameter.Name }}=", Uri.EscapeDataString(Convert.ToBase64String({{ $camelcase }})), "&");
+            }
+                {{- else if eq $parameter.Type "integer" }}
+            if ({{ $camelcase }} != null) {
+                queryParams = string.Concat(queryParams, "{{- $parameter.Name }}=", {{ $camelcase }}, "&");
+            }
+                {{- else if eq $parameter.Type "number" }}
             if ({{ $camelcase }} != null) {
                 queryParams = string.Concat(queryParams, "{{- $parameter.Name }}=", {{ $camelcase }}, "&");
             }
@@ -353,11 +504,22 @@ namespace Nakama
             {{- end }}
             {{- end }}
 
-            {{- if $operation.Responses.Ok.Schema.Ref }}
-            var contents = await HttpAdapter.SendAsync(method, uri, headers, content, _timeout);
-            return contents.FromJson<{{ $operation.Responses.Ok.Schema.Ref | cleanRef }}>();
+            {{- if and $errResponse $errResponse.Schema }}
+            try
+            {
+            {{- end }}
+            {{- if and $okResponse $okResponse.Schema }}
+                var contents = await HttpAdapter.SendAsync(method, uri, headers, content, _timeout);
+                return contents.FromJson<{{ $okResponse.Schema.Ref | cleanRef }}>();
             {{- else }}
-            await HttpAdapter.SendAsync(method, uri, headers, content, _timeout);
+                await HttpAdapter.SendAsync(method, uri, headers, content, _timeout);
+            {{- end}}
+            {{- if and $errResponse $errResponse.Schema }}
+            }
+            catch (ApiResponseException e)
+            {
+                throw new ApiResponseException<{{ $errResponse.Schema.Ref | cleanRef }}>(e.StatusCode, e.Message, e.GrpcStatusCode, e.Message.FromJson<{{ $errResponse.Schema.Ref | cleanRef }}>());
+            }
             {{- end}}
         }
         {{- end }}
@@ -366,12 +528,115 @@ namespace Nakama
 }
 `
 
+// Schema is the version-agnostic, in-memory representation of an API document that the
+// template renders from. Both the Swagger 2.0 and OpenAPI 3.0 parsers produce one of these
+// so the rest of the generator never needs to know which spec version it came from.
+type Schema struct {
+	Paths       map[string]map[string]*Operation
+	Definitions map[string]*Definition
+}
+
+// Definition is a named schema, usually from `#/definitions/` (Swagger 2.0) or
+// `#/components/schemas/` (OpenAPI 3.0). When Discriminator is set, this definition
+// represents a `oneOf`/`anyOf` union rather than a plain object and is rendered as a
+// discriminated wrapper class instead of an interface/class pair.
+type Definition struct {
+	Description   string
+	Format        string
+	Deprecated    bool
+	Properties    map[string]*Property
+	Discriminator *Discriminator
+	OneOf         []string
+	EnumMembers   []EnumMember
+}
+
+// Discriminator carries the `discriminator.propertyName` used to pick which member of a
+// `oneOf`/`anyOf` union a given JSON payload actually is.
+type Discriminator struct {
+	PropertyName string
+}
+
+// EnumMember is a single value of a generated C# enum. Identifier is the C# member name
+// (from `x-enum-varnames` when present, otherwise derived from Value), Value is the wire
+// value emitted in `[EnumMember(Value=...)]`, and Description comes from `x-enum-descriptions`.
+type EnumMember struct {
+	Identifier  string
+	Value       string
+	Description string
+}
+
+// Property describes a schema used for a definition's property, a parameter's schema, or a
+// response's schema.
+type Property struct {
+	Type                 string
+	Format               string
+	Ref                  string `json:"$ref"`
+	Description          string
+	Nullable             bool
+	Deprecated           bool
+	Items                *Property
+	AdditionalProperties *Property
+	EnumMembers          []EnumMember
+}
+
+// Parameter describes an operation parameter, normalized from either a Swagger 2.0
+// parameter object (where type information sits directly on the parameter) or an
+// OpenAPI 3.0 parameter object (where it sits under `schema`).
+type Parameter struct {
+	Name                 string
+	In                   string
+	Required             bool
+	Type                 string
+	Format               string
+	Description          string
+	Deprecated           bool
+	Items                *Property
+	Schema               *Property
+	AdditionalProperties *Property
+}
+
+// Response is a single entry from an operation's `responses` map, keyed by status code
+// (or "default").
+type Response struct {
+	Description string
+	Schema      *Property
+}
+
+// Operation is a single method (GET, POST, ...) under a path.
+type Operation struct {
+	Summary     string
+	Description string
+	OperationId string
+	Deprecated  bool
+	Responses   map[string]*Response
+	// SuccessResponse is the response for the lowest declared 2xx status code that has a
+	// schema (else the lowest declared 2xx), or nil if the operation declares none.
+	SuccessResponse *Response
+	// ErrorResponse is the first non-2xx response with a schema (falling back to "default"),
+	// used to generate a typed ApiResponseException<TError>. Nil if no error schema is declared.
+	ErrorResponse *Response
+	Parameters    []*Parameter
+	Security      []map[string][]struct{}
+}
+
 func convertRefToClassName(input string) (className string) {
-	cleanRef := strings.TrimPrefix(input, "#/definitions/")
-	className = strings.Title(cleanRef)
+	className = strings.Title(refTargetName(input))
 	return
 }
 
+// refTargetName strips the Swagger 2.0 (`#/definitions/`) or OpenAPI 3.0
+// (`#/components/schemas/`) prefix from a `$ref`, without altering its case. Used to look
+// up a ref's target in a schemas map that is still keyed by its original spec name.
+func refTargetName(ref string) string {
+	_, fragment := splitRef(ref)
+	if fragment == "" {
+		fragment = ref
+	}
+	cleaned := strings.TrimPrefix(fragment, "#/definitions/")
+	cleaned = strings.TrimPrefix(cleaned, "#/components/schemas/")
+	return cleaned
+}
+
 func snakeCaseToCamelCase(input string) (camelCase string) {
 	isToUpper := false
 	for k, v := range input {
@@ -420,6 +685,892 @@ func stripNewlines(input string) (output string) {
 	return
 }
 
+// primType maps a schema's (type, format) pair to the most specific C# type the generator
+// can emit, e.g. "integer"/"int64" -> long rather than the default "int", so Nakama's 64-bit
+// leaderboard scores and Unix timestamps don't silently truncate, and "string"/"date-time" ->
+// DateTime instead of a raw string.
+func primType(t, format string) string {
+	switch t {
+	case "integer":
+		if format == "int64" {
+			return "long"
+		}
+		return "int"
+	case "number":
+		if format == "float" {
+			return "float"
+		}
+		return "double"
+	case "string":
+		switch format {
+		case "date-time":
+			return "DateTime"
+		case "uuid":
+			return "Guid"
+		case "byte", "binary":
+			return "byte[]"
+		default:
+			return "string"
+		}
+	case "boolean":
+		return "bool"
+	default:
+		return t
+	}
+}
+
+// isValueType reports whether a C# type name produced by primType is a value type, i.e.
+// whether a nullable property of that type needs the "?" suffix.
+func isValueType(cstype string) bool {
+	switch cstype {
+	case "int", "long", "float", "double", "bool", "DateTime", "Guid":
+		return true
+	default:
+		return false
+	}
+}
+
+// buildEnumMembers pairs a schema's `enum` values with the `x-enum-varnames` and
+// `x-enum-descriptions` vendor extensions to produce the identifiers and doc comments for a
+// generated C# enum.
+func buildEnumMembers(values, varNames, descriptions []string) []EnumMember {
+	if len(values) == 0 {
+		return nil
+	}
+
+	members := make([]EnumMember, 0, len(values))
+	for i, value := range values {
+		identifier := ""
+		if i < len(varNames) && varNames[i] != "" {
+			identifier = snakeCaseToPascalCase(varNames[i])
+		} else {
+			identifier = snakeCaseToPascalCase(sanitizeEnumValue(value))
+		}
+
+		description := ""
+		if i < len(descriptions) {
+			description = descriptions[i]
+		}
+
+		members = append(members, EnumMember{
+			Identifier:  identifier,
+			Value:       value,
+			Description: description,
+		})
+	}
+	return members
+}
+
+// sanitizeEnumValue turns a raw enum wire value (which may contain characters invalid in a
+// C# identifier, e.g. "some-value", or start with a digit) into something
+// snakeCaseToPascalCase can turn into a valid identifier.
+func sanitizeEnumValue(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r == '-' || r == ' ' || r == '.' {
+			b.WriteRune('_')
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	out := b.String()
+	if out == "" || (out[0] >= '0' && out[0] <= '9') {
+		out = "_" + out
+	}
+	return out
+}
+
+// deriveResponses picks an operation's success response (the lowest declared 2xx status
+// code that has a schema, else the lowest declared 2xx) and error response (the first non-2xx response with a schema, falling back to
+// "default") out of its full responses map.
+func deriveResponses(responses map[string]*Response) (success *Response, errResp *Response) {
+	var successCodes []string
+	var errorCodes []string
+	for code := range responses {
+		if code == "default" {
+			continue
+		}
+		if strings.HasPrefix(code, "2") {
+			successCodes = append(successCodes, code)
+		} else {
+			errorCodes = append(errorCodes, code)
+		}
+	}
+	sort.Strings(successCodes)
+	sort.Strings(errorCodes)
+
+	for _, code := range successCodes {
+		if responses[code].Schema != nil {
+			success = responses[code]
+			break
+		}
+	}
+	if success == nil && len(successCodes) > 0 {
+		success = responses[successCodes[0]]
+	}
+
+	for _, code := range errorCodes {
+		if responses[code].Schema != nil {
+			errResp = responses[code]
+			break
+		}
+	}
+	if errResp == nil {
+		if def, ok := responses["default"]; ok && def.Schema != nil {
+			errResp = def
+		}
+	}
+
+	return success, errResp
+}
+
+// deprecatedParameterMessage returns an [Obsolete] message listing an operation's deprecated
+// parameters, or "" if none are deprecated. C# has no way to mark a single parameter
+// obsolete, so a deprecated parameter is surfaced by marking the whole method instead.
+func deprecatedParameterMessage(params []*Parameter) string {
+	var names []string
+	for _, p := range params {
+		if p.Deprecated {
+			names = append(names, p.Name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Deprecated parameter(s): %s.", strings.Join(names, ", "))
+}
+
+// detectSpecVersion inspects the root `swagger` / `openapi` field to decide which parser
+// should handle the document.
+func detectSpecVersion(content []byte) (string, error) {
+	var probe struct {
+		Swagger string `json:"swagger"`
+		OpenAPI string `json:"openapi"`
+	}
+	if err := json.Unmarshal(content, &probe); err != nil {
+		return "", err
+	}
+	if probe.OpenAPI != "" {
+		return probe.OpenAPI, nil
+	}
+	if probe.Swagger != "" {
+		return probe.Swagger, nil
+	}
+	return "", fmt.Errorf("unable to detect spec version: no 'swagger' or 'openapi' root field found")
+}
+
+// -- Multi-file merging and external $ref resolution ---------------------------------------
+
+// definitionMerger accumulates Definitions from multiple parsed documents into a single map,
+// deduplicating identical definitions by structural hash and renaming on name collisions by
+// prefixing with the source file's basename.
+type definitionMerger struct {
+	definitions map[string]*Definition
+	hashes      map[string]string // structural hash -> name already chosen for it
+}
+
+func newDefinitionMerger() *definitionMerger {
+	return &definitionMerger{
+		definitions: map[string]*Definition{},
+		hashes:      map[string]string{},
+	}
+}
+
+// add merges def into the map under name, returning the name it was actually stored under: name
+// itself, a "<basename>_name" rename on collision, or the name an earlier structurally-identical
+// definition already claimed. Callers must rewrite any local $ref to name that pointed at this
+// definition to use the returned name instead.
+func (m *definitionMerger) add(source, name string, def *Definition) string {
+	hash := structuralHash(def)
+	if existing, ok := m.hashes[hash]; ok {
+		return existing
+	}
+
+	finalName := name
+	if _, collision := m.definitions[finalName]; collision {
+		finalName = fmt.Sprintf("%s_%s", sourceBaseName(source), name)
+	}
+
+	m.definitions[finalName] = def
+	m.hashes[hash] = finalName
+	return finalName
+}
+
+func sourceBaseName(source string) string {
+	base := filepath.Base(source)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+func structuralHash(def *Definition) string {
+	data, _ := json.Marshal(def)
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// mergeSchemas combines the Schema parsed from each CLI input file into one. The returned
+// merger keeps accumulating definitions as resolveExternalRefs pulls in more files.
+func mergeSchemas(schemas []*Schema, sources []string) (*Schema, *definitionMerger) {
+	merged := &Schema{
+		Paths: map[string]map[string]*Operation{},
+	}
+	merger := newDefinitionMerger()
+
+	for i, s := range schemas {
+		rename := map[string]string{}
+		for name, def := range s.Definitions {
+			if finalName := merger.add(sources[i], name, def); finalName != name {
+				rename[name] = finalName
+			}
+		}
+		if len(rename) > 0 {
+			rewriteLocalRefs(s, rename)
+		}
+
+		for url, methods := range s.Paths {
+			if merged.Paths[url] == nil {
+				merged.Paths[url] = map[string]*Operation{}
+			}
+			for method, op := range methods {
+				merged.Paths[url][method] = op
+			}
+		}
+	}
+
+	merged.Definitions = merger.definitions
+	return merged, merger
+}
+
+// mapRefs rewrites every $ref reachable from schema (the same set walkRefs visits) in place,
+// through transform.
+func mapRefs(schema *Schema, transform func(ref string) string) {
+	var rewriteProperty func(p *Property)
+	rewriteProperty = func(p *Property) {
+		if p == nil {
+			return
+		}
+		p.Ref = transform(p.Ref)
+		rewriteProperty(p.Items)
+		rewriteProperty(p.AdditionalProperties)
+	}
+
+	for _, def := range schema.Definitions {
+		for _, prop := range def.Properties {
+			rewriteProperty(prop)
+		}
+	}
+	for _, methods := range schema.Paths {
+		for _, op := range methods {
+			for _, resp := range op.Responses {
+				rewriteProperty(resp.Schema)
+			}
+			for _, param := range op.Parameters {
+				rewriteProperty(param.Schema)
+				rewriteProperty(param.Items)
+				rewriteProperty(param.AdditionalProperties)
+			}
+		}
+	}
+}
+
+// rewriteLocalRefs patches every *local* $ref in schema (i.e. one with no file part, so it was
+// written expecting to resolve within schema's own document) whose target name was renamed by
+// definitionMerger.add, so properties, parameters, and responses still point at the definition
+// they meant after a name collision (or a cross-file structural duplicate) moved it.
+func rewriteLocalRefs(schema *Schema, rename map[string]string) {
+	mapRefs(schema, func(ref string) string {
+		if ref == "" {
+			return ref
+		}
+		file, fragment := splitRef(ref)
+		if file != "" {
+			return ref
+		}
+		newName, ok := rename[refTargetName(ref)]
+		if !ok {
+			return ref
+		}
+		return strings.Replace(fragment, refTargetName(ref), newName, 1)
+	})
+}
+
+// rewriteCrossFileRefs patches every $ref in schema that points into another file (e.g.
+// "common.json#/definitions/Foo") whose target was renamed when that file's definitions were
+// merged in, keyed by renamesBySource[file][originalName].
+func rewriteCrossFileRefs(schema *Schema, renamesBySource map[string]map[string]string) {
+	mapRefs(schema, func(ref string) string {
+		if ref == "" {
+			return ref
+		}
+		file, fragment := splitRef(ref)
+		if file == "" {
+			return ref
+		}
+		rename, ok := renamesBySource[file]
+		if !ok {
+			return ref
+		}
+		newName, ok := rename[refTargetName(ref)]
+		if !ok {
+			return ref
+		}
+		return file + strings.Replace(fragment, refTargetName(ref), newName, 1)
+	})
+}
+
+// walkRefs invokes visit with every non-empty $ref string reachable from schema: each
+// definition's properties (recursing into Items/AdditionalProperties), and each operation's
+// parameter and response schemas.
+func walkRefs(schema *Schema, visit func(ref string)) {
+	var visitProperty func(p *Property)
+	visitProperty = func(p *Property) {
+		if p == nil {
+			return
+		}
+		if p.Ref != "" {
+			visit(p.Ref)
+		}
+		visitProperty(p.Items)
+		visitProperty(p.AdditionalProperties)
+	}
+
+	for _, def := range schema.Definitions {
+		for _, prop := range def.Properties {
+			visitProperty(prop)
+		}
+	}
+	for _, methods := range schema.Paths {
+		for _, op := range methods {
+			for _, resp := range op.Responses {
+				visitProperty(resp.Schema)
+			}
+			for _, param := range op.Parameters {
+				visitProperty(param.Schema)
+				visitProperty(param.Items)
+				visitProperty(param.AdditionalProperties)
+			}
+		}
+	}
+}
+
+// splitRef splits a $ref into its file part and its JSON-Pointer fragment (including the
+// leading "#"). A purely local ref such as "#/definitions/Foo" has an empty file part.
+func splitRef(ref string) (file, fragment string) {
+	idx := strings.Index(ref, "#")
+	if idx < 0 {
+		return ref, ""
+	}
+	return ref[:idx], ref[idx:]
+}
+
+// fetchRef reads the document a $ref's file part points to, resolving relative paths against
+// baseDir (the directory of the first CLI input) and fetching "http://"/"https://" URLs over
+// the network.
+func fetchRef(file, baseDir string) ([]byte, error) {
+	if strings.HasPrefix(file, "http://") || strings.HasPrefix(file, "https://") {
+		resp, err := http.Get(file)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %d fetching %s", resp.StatusCode, file)
+		}
+		return ioutil.ReadAll(resp.Body)
+	}
+
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	return ioutil.ReadFile(path)
+}
+
+// resolveExternalRefs follows every $ref that points outside the current document — a
+// sibling spec file (e.g. "common.json#/definitions/Foo") or a remote URL — loading and
+// merging the referenced file's definitions into merger so the template can render them as if
+// they'd always been local. It keeps resolving newly-merged-in files' own refs until no new
+// ones are discovered, and errors out on a file-load cycle.
+func resolveExternalRefs(schema *Schema, merger *definitionMerger, baseDir string) error {
+	loaded := map[string]bool{}
+	loading := map[string]bool{}
+	renamesBySource := map[string]map[string]string{}
+	var externalSchemas []*Schema
+
+	var resolveFile func(file string) error
+	resolveFile = func(file string) error {
+		if loaded[file] {
+			return nil
+		}
+		if loading[file] {
+			return fmt.Errorf("cyclic $ref: %q can't be resolved (no interface indirection breaks a file-load cycle)", file)
+		}
+		loading[file] = true
+		defer delete(loading, file)
+
+		content, err := fetchRef(file, baseDir)
+		if err != nil {
+			return fmt.Errorf("unable to resolve external $ref to %q: %w", file, err)
+		}
+
+		version, err := detectSpecVersion(content)
+		if err != nil {
+			return fmt.Errorf("unable to resolve external $ref to %q: %w", file, err)
+		}
+
+		var external *Schema
+		if strings.HasPrefix(version, "3.") {
+			external, err = parseOpenAPI3(content)
+		} else {
+			external, err = parseSwagger2(content)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to parse external spec %q: %w", file, err)
+		}
+
+		rename := map[string]string{}
+		for name, def := range external.Definitions {
+			if finalName := merger.add(file, name, def); finalName != name {
+				rename[name] = finalName
+			}
+		}
+		if len(rename) > 0 {
+			rewriteLocalRefs(external, rename)
+			renamesBySource[file] = rename
+		}
+		externalSchemas = append(externalSchemas, external)
+
+		var walkErr error
+		walkRefs(external, func(ref string) {
+			if walkErr != nil {
+				return
+			}
+			refFile, _ := splitRef(ref)
+			if refFile == "" {
+				return
+			}
+			if err := resolveFile(refFile); err != nil {
+				walkErr = err
+			}
+		})
+		loaded[file] = true
+		return walkErr
+	}
+
+	var err error
+	walkRefs(schema, func(ref string) {
+		if err != nil {
+			return
+		}
+		file, _ := splitRef(ref)
+		if file == "" {
+			return
+		}
+		if resolveErr := resolveFile(file); resolveErr != nil {
+			err = resolveErr
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// Every file is loaded now, so every rename decision is known: fix up cross-file $refs (in
+	// the main schema or in another external file) that pointed at a definition whose file got
+	// merged in under a different name.
+	if len(renamesBySource) > 0 {
+		rewriteCrossFileRefs(schema, renamesBySource)
+		for _, external := range externalSchemas {
+			rewriteCrossFileRefs(external, renamesBySource)
+		}
+	}
+
+	schema.Definitions = merger.definitions
+	return nil
+}
+
+// -- Swagger 2.0 --------------------------------------------------------------------------
+
+type swagger2Document struct {
+	Swagger     string                                    `json:"swagger"`
+	Paths       map[string]map[string]*swagger2Operation  `json:"paths"`
+	Definitions map[string]*swagger2Schema                `json:"definitions"`
+}
+
+type swagger2Schema struct {
+	Type                 string                     `json:"type"`
+	Format               string                     `json:"format"`
+	Ref                  string                     `json:"$ref"`
+	Description          string                     `json:"description"`
+	Deprecated           bool                       `json:"deprecated"`
+	Properties           map[string]*swagger2Schema `json:"properties"`
+	Items                *swagger2Schema            `json:"items"`
+	AdditionalProperties *swagger2Schema            `json:"additionalProperties"`
+	Enum                 []string                   `json:"enum"`
+	XEnumVarNames        []string                   `json:"x-enum-varnames"`
+	XEnumDescriptions    []string                   `json:"x-enum-descriptions"`
+}
+
+type swagger2Parameter struct {
+	Name                 string          `json:"name"`
+	In                   string          `json:"in"`
+	Required             bool            `json:"required"`
+	Type                 string          `json:"type"`
+	Format               string          `json:"format"`
+	Description          string          `json:"description"`
+	Deprecated           bool            `json:"deprecated"`
+	Items                *swagger2Schema `json:"items"`
+	Schema               *swagger2Schema `json:"schema"`
+	AdditionalProperties *swagger2Schema `json:"additionalProperties"`
+}
+
+type swagger2Operation struct {
+	Summary     string `json:"summary"`
+	Description string `json:"description"`
+	OperationId string `json:"operationId"`
+	Deprecated  bool   `json:"deprecated"`
+	Responses   map[string]*struct {
+		Description string          `json:"description"`
+		Schema      *swagger2Schema `json:"schema"`
+	} `json:"responses"`
+	Parameters []*swagger2Parameter     `json:"parameters"`
+	Security   []map[string][]struct{} `json:"security"`
+}
+
+func parseSwagger2(content []byte) (*Schema, error) {
+	var doc swagger2Document
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{
+		Paths:       map[string]map[string]*Operation{},
+		Definitions: map[string]*Definition{},
+	}
+
+	for name, def := range doc.Definitions {
+		schema.Definitions[name] = &Definition{
+			Description: def.Description,
+			Format:      def.Format,
+			Deprecated:  def.Deprecated,
+			Properties:  convertSwagger2Properties(def.Properties),
+			EnumMembers: buildEnumMembers(def.Enum, def.XEnumVarNames, def.XEnumDescriptions),
+		}
+	}
+
+	for url, methods := range doc.Paths {
+		schema.Paths[url] = map[string]*Operation{}
+		for method, op := range methods {
+			operation := &Operation{
+				Summary:     op.Summary,
+				Description: op.Description,
+				OperationId: op.OperationId,
+				Deprecated:  op.Deprecated,
+				Security:    op.Security,
+				Responses:   map[string]*Response{},
+			}
+			for status, resp := range op.Responses {
+				operation.Responses[status] = &Response{
+					Description: resp.Description,
+					Schema:      convertSwagger2Schema(resp.Schema),
+				}
+			}
+			for _, p := range op.Parameters {
+				operation.Parameters = append(operation.Parameters, &Parameter{
+					Name:                 p.Name,
+					In:                   p.In,
+					Required:             p.Required,
+					Type:                 p.Type,
+					Format:               p.Format,
+					Description:          p.Description,
+					Deprecated:           p.Deprecated,
+					Items:                convertSwagger2Schema(p.Items),
+					Schema:               convertSwagger2Schema(p.Schema),
+					AdditionalProperties: convertSwagger2Schema(p.AdditionalProperties),
+				})
+			}
+			operation.SuccessResponse, operation.ErrorResponse = deriveResponses(operation.Responses)
+			schema.Paths[url][method] = operation
+		}
+	}
+
+	return schema, nil
+}
+
+func convertSwagger2Properties(props map[string]*swagger2Schema) map[string]*Property {
+	out := map[string]*Property{}
+	for name, p := range props {
+		out[name] = convertSwagger2Schema(p)
+	}
+	return out
+}
+
+func convertSwagger2Schema(s *swagger2Schema) *Property {
+	if s == nil {
+		return nil
+	}
+	return &Property{
+		Type:                 s.Type,
+		Format:               s.Format,
+		Ref:                  s.Ref,
+		Description:          s.Description,
+		Deprecated:           s.Deprecated,
+		Items:                convertSwagger2Schema(s.Items),
+		AdditionalProperties: convertSwagger2Schema(s.AdditionalProperties),
+		EnumMembers:          buildEnumMembers(s.Enum, s.XEnumVarNames, s.XEnumDescriptions),
+	}
+}
+
+// -- OpenAPI 3.0 ----------------------------------------------------------------------------
+
+type openapi3Document struct {
+	OpenAPI    string `json:"openapi"`
+	Components struct {
+		Schemas map[string]*oas3Schema `json:"schemas"`
+	} `json:"components"`
+	Paths map[string]map[string]*oas3Operation `json:"paths"`
+}
+
+type oas3Schema struct {
+	Type                 string                 `json:"type"`
+	Format               string                 `json:"format"`
+	Ref                  string                 `json:"$ref"`
+	Description          string                 `json:"description"`
+	Nullable             bool                   `json:"nullable"`
+	Deprecated           bool                   `json:"deprecated"`
+	Properties           map[string]*oas3Schema `json:"properties"`
+	Items                *oas3Schema            `json:"items"`
+	AdditionalProperties *oas3Schema            `json:"additionalProperties"`
+	AllOf                []*oas3Schema          `json:"allOf"`
+	OneOf                []*oas3Schema          `json:"oneOf"`
+	AnyOf                []*oas3Schema          `json:"anyOf"`
+	Discriminator        *struct {
+		PropertyName string `json:"propertyName"`
+	} `json:"discriminator"`
+	Enum              []string `json:"enum"`
+	XEnumVarNames     []string `json:"x-enum-varnames"`
+	XEnumDescriptions []string `json:"x-enum-descriptions"`
+}
+
+type oas3Content struct {
+	Schema *oas3Schema `json:"schema"`
+}
+
+type oas3Parameter struct {
+	Name        string      `json:"name"`
+	In          string      `json:"in"`
+	Required    bool        `json:"required"`
+	Description string      `json:"description"`
+	Deprecated  bool        `json:"deprecated"`
+	Schema      *oas3Schema `json:"schema"`
+}
+
+type oas3Operation struct {
+	Summary     string           `json:"summary"`
+	Description string           `json:"description"`
+	OperationId string           `json:"operationId"`
+	Deprecated  bool             `json:"deprecated"`
+	Parameters  []*oas3Parameter `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]oas3Content `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]*struct {
+		Description string                 `json:"description"`
+		Content     map[string]oas3Content `json:"content"`
+	} `json:"responses"`
+	Security []map[string][]struct{} `json:"security"`
+}
+
+func parseOpenAPI3(content []byte) (*Schema, error) {
+	var doc openapi3Document
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{
+		Paths:       map[string]map[string]*Operation{},
+		Definitions: map[string]*Definition{},
+	}
+
+	for name, def := range doc.Components.Schemas {
+		converted, err := convertOAS3Definition(def, doc.Components.Schemas)
+		if err != nil {
+			return nil, fmt.Errorf("definition %q: %w", name, err)
+		}
+		schema.Definitions[name] = converted
+	}
+
+	for url, methods := range doc.Paths {
+		schema.Paths[url] = map[string]*Operation{}
+		for method, op := range methods {
+			operation := &Operation{
+				Summary:     op.Summary,
+				Description: op.Description,
+				OperationId: op.OperationId,
+				Deprecated:  op.Deprecated,
+				Security:    op.Security,
+				Responses:   map[string]*Response{},
+			}
+			for status, resp := range op.Responses {
+				operation.Responses[status] = &Response{
+					Description: resp.Description,
+					Schema:      convertOAS3Schema(resp.Content["application/json"].Schema),
+				}
+			}
+			for _, p := range op.Parameters {
+				operation.Parameters = append(operation.Parameters, convertOAS3Parameter(p))
+			}
+			if op.RequestBody != nil {
+				if body, ok := op.RequestBody.Content["application/json"]; ok {
+					operation.Parameters = append(operation.Parameters, &Parameter{
+						Name:     "body",
+						In:       "body",
+						Required: true,
+						Schema:   convertOAS3Schema(body.Schema),
+					})
+				}
+			}
+			operation.SuccessResponse, operation.ErrorResponse = deriveResponses(operation.Responses)
+			schema.Paths[url][method] = operation
+		}
+	}
+
+	return schema, nil
+}
+
+func convertOAS3Parameter(p *oas3Parameter) *Parameter {
+	param := &Parameter{
+		Name:        p.Name,
+		In:          p.In,
+		Required:    p.Required,
+		Description: p.Description,
+		Deprecated:  p.Deprecated,
+	}
+	if p.Schema != nil {
+		param.Type = p.Schema.Type
+		param.Format = p.Schema.Format
+		param.Items = convertOAS3Schema(p.Schema.Items)
+		param.AdditionalProperties = convertOAS3Schema(p.Schema.AdditionalProperties)
+	}
+	return param
+}
+
+func convertOAS3Schema(s *oas3Schema) *Property {
+	if s == nil {
+		return nil
+	}
+	return &Property{
+		Type:                 s.Type,
+		Format:               s.Format,
+		Ref:                  s.Ref,
+		Description:          s.Description,
+		Nullable:             s.Nullable,
+		Deprecated:           s.Deprecated,
+		Items:                convertOAS3Schema(s.Items),
+		AdditionalProperties: convertOAS3Schema(s.AdditionalProperties),
+		EnumMembers:          buildEnumMembers(s.Enum, s.XEnumVarNames, s.XEnumDescriptions),
+	}
+}
+
+// convertOAS3Definition turns a `components/schemas` entry into a Definition, flattening
+// `allOf` members (including ones reached through a `$ref`) into a single property set, and
+// delegating to convertOAS3Union for `oneOf`/`anyOf`. An `allOf` chain must actually terminate
+// to be flattened, so a cycle here is an error rather than something the generator can paper
+// over.
+func convertOAS3Definition(def *oas3Schema, all map[string]*oas3Schema) (*Definition, error) {
+	if len(def.OneOf) > 0 || len(def.AnyOf) > 0 {
+		return convertOAS3Union(def)
+	}
+
+	if len(def.Enum) > 0 {
+		return &Definition{
+			Description: def.Description,
+			Format:      def.Format,
+			Deprecated:  def.Deprecated,
+			EnumMembers: buildEnumMembers(def.Enum, def.XEnumVarNames, def.XEnumDescriptions),
+		}, nil
+	}
+
+	properties := map[string]*Property{}
+	description := def.Description
+	deprecated := def.Deprecated
+	visiting := map[string]bool{}
+
+	var collect func(s *oas3Schema, refName string) error
+	collect = func(s *oas3Schema, refName string) error {
+		if s == nil {
+			return nil
+		}
+		if refName != "" {
+			if visiting[refName] {
+				return fmt.Errorf("cyclic allOf $ref involving %q can't be flattened", refName)
+			}
+			visiting[refName] = true
+			defer delete(visiting, refName)
+		}
+		if s.Ref != "" {
+			refName := refTargetName(s.Ref)
+			target, ok := all[refName]
+			if !ok {
+				if file, _ := splitRef(s.Ref); file != "" {
+					return fmt.Errorf("allOf $ref %q points into another file (%q); cross-file allOf flattening isn't supported, only plain property $refs are resolved across files", s.Ref, file)
+				}
+				return fmt.Errorf("allOf $ref %q does not resolve to a known definition", s.Ref)
+			}
+			return collect(target, refName)
+		}
+		for _, member := range s.AllOf {
+			if err := collect(member, ""); err != nil {
+				return err
+			}
+		}
+		for propName, prop := range s.Properties {
+			properties[propName] = convertOAS3Schema(prop)
+		}
+		if description == "" {
+			description = s.Description
+		}
+		deprecated = deprecated || s.Deprecated
+		return nil
+	}
+	if err := collect(def, ""); err != nil {
+		return nil, err
+	}
+
+	return &Definition{
+		Description: description,
+		Deprecated:  deprecated,
+		Properties:  properties,
+	}, nil
+}
+
+// convertOAS3Union builds the Definition for a `oneOf`/`anyOf` schema, rendered as a wrapper
+// class that picks one of its members based on the `discriminator.propertyName` value. The
+// discriminator is required here even though the OpenAPI 3.0 spec allows omitting it, since the
+// generated wrapper has no other way to pick which member a given JSON payload is.
+func convertOAS3Union(def *oas3Schema) (*Definition, error) {
+	if def.Discriminator == nil || def.Discriminator.PropertyName == "" {
+		return nil, fmt.Errorf("oneOf/anyOf schema %q has no discriminator.propertyName; the generator can't pick a member without one", def.Description)
+	}
+
+	members := def.OneOf
+	if len(members) == 0 {
+		members = def.AnyOf
+	}
+
+	var memberNames []string
+	for _, m := range members {
+		memberNames = append(memberNames, convertRefToClassName(m.Ref))
+	}
+
+	return &Definition{
+		Description: def.Description,
+		Deprecated:  def.Deprecated,
+		Discriminator: &Discriminator{
+			PropertyName: def.Discriminator.PropertyName,
+		},
+		OneOf: memberNames,
+	}, nil
+}
+
 func main() {
 	// Argument flags
 	var output = flag.String("output", "", "The output for generated code.")
@@ -433,73 +1584,53 @@ func main() {
 		return
 	}
 
-	input := inputs[0]
-	content, err := ioutil.ReadFile(input)
-	if err != nil {
-		fmt.Printf("Unable to read file: %s\n", err)
-		return
-	}
+	var schemas []*Schema
+	for _, input := range inputs {
+		content, err := ioutil.ReadFile(input)
+		if err != nil {
+			fmt.Printf("Unable to read file: %s\n", err)
+			return
+		}
 
-	var schema struct {
-		Paths map[string]map[string]struct {
-			Summary     string
-			OperationId string
-			Responses   struct {
-				Ok struct {
-					Schema struct {
-						Ref string `json:"$ref"`
-					}
-				} `json:"200"`
-			}
-			Parameters []struct {
-				Name     string
-				In       string
-				Required bool
-				Type     string   // used with primitives
-				Items    struct { // used with type "array"
-					Type string
-				}
-				Schema struct { // used with http body
-					Type string
-					Ref  string `json:"$ref"`
-				}
-                Format   string // used with type "boolean"
-			}
-			Security []map[string][]struct {
-			}
+		version, err := detectSpecVersion(content)
+		if err != nil {
+			fmt.Printf("Unable to decode input %s : %s\n", input, err)
+			return
 		}
-		Definitions map[string]struct {
-			Properties map[string]struct {
-				Type  string
-				Ref   string   `json:"$ref"` // used with object
-				Items struct { // used with type "array"
-					Type string
-					Ref  string `json:"$ref"`
-				}
-                AdditionalProperties struct {
-                    Type string // used with type "map"
-                }
-				Format      string // used with type "boolean"
-				Description string
-			}
-			Description string
+
+		var schema *Schema
+		if strings.HasPrefix(version, "3.") {
+			schema, err = parseOpenAPI3(content)
+		} else {
+			schema, err = parseSwagger2(content)
+		}
+		if err != nil {
+			fmt.Printf("Unable to decode input %s : %s\n", input, err)
+			return
 		}
+
+		schemas = append(schemas, schema)
 	}
 
-	if err := json.Unmarshal(content, &schema); err != nil {
-		fmt.Printf("Unable to decode input %s : %s\n", input, err)
+	schema, merger := mergeSchemas(schemas, inputs)
+
+	if err := resolveExternalRefs(schema, merger, filepath.Dir(inputs[0])); err != nil {
+		fmt.Printf("Unable to resolve external $ref: %s\n", err)
 		return
 	}
 
 	fmap := template.FuncMap{
-		"camelCase":     snakeCaseToCamelCase,
-		"cleanRef":      convertRefToClassName,
-		"pascalCase":    snakeCaseToPascalCase,
-		"stripNewlines": stripNewlines,
-		"title":         strings.Title,
-		"uppercase":     strings.ToUpper,
-	}
-	tmpl, err := template.New(input).Funcs(fmap).Parse(codeTemplate)
+		"camelCase":                  snakeCaseToCamelCase,
+		"cleanRef":                   convertRefToClassName,
+		"pascalCase":                 snakeCaseToPascalCase,
+		"stripNewlines":              stripNewlines,
+		"title":                      strings.Title,
+		"uppercase":                  strings.ToUpper,
+		"primType":                   primType,
+		"isValueType":                isValueType,
+		"deprecatedParameterMessage": deprecatedParameterMessage,
+	}
+	tmpl, err := template.New(inputs[0]).Funcs(fmap).Parse(codeTemplate)
 	if err != nil {
 		fmt.Printf("Template parse error: %s\n", err)
 		return